@@ -1,107 +1,427 @@
 package main
 
 import (
-	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
-	"sort"
+	"time"
+
+	"github.com/junli1026/Quark/pkg/quarklog"
+	"github.com/junli1026/Quark/pkg/quarklog/index"
+	"github.com/junli1026/Quark/pkg/quarklog/timeline"
 )
 
-type line struct {
-	num int
-	str string
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "timeline":
+			if err := runTimeline(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "search":
+			if err := runSearch(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	runDump(os.Args[1:])
 }
 
-type Lines []line
-func (a Lines) Len() int           { return len(a) }
-func (a Lines) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a Lines) Less(i, j int) bool { return a[i].num < a[j].num }
+func runDump(args []string) {
+	fs := flag.NewFlagSet("quarklog", flag.ExitOnError)
+	dir := fs.String("dir", "/var/log/quark", "directory containing the quark log and its rotated siblings")
+	base := fs.String("base", "quark.log", "base name of the log file within --dir")
+	follow := fs.Bool("follow", false, "tail the live log file for new entries instead of exiting at EOF")
+	level := fs.String("level", "", "comma-separated list of levels to include, e.g. ERROR,DEBUG (default: all)")
+	since := fs.String("since", "", "only include entries at or after this time (duration like 10m, or an absolute timestamp)")
+	until := fs.String("until", "", "only include entries at or before this time (duration like 10m, or an absolute timestamp)")
+	vcpu := fs.String("vcpu", "", "only include entries for this vCPU id")
+	task := fs.String("task", "", "only include entries for this task id")
+	grep := fs.String("grep", "", "only include entries whose message matches this regexp")
+	output := fs.String("output", "text", "output format: text, json, or csv")
+	fs.Parse(args)
 
-func main() {
-	file, err := os.Open("/var/log/quark/quark.log")
+	f, err := newFilter(*level, *since, *until, *vcpu, *task, *grep)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer file.Close()
 
-	m := make(map[string]line)
-	vcpus := make(map[string]line)
-	scanner := bufio.NewScanner(file)
-	num := 0
-	for scanner.Scan() {
-		num += 1
-		fullstr := scanner.Text()
-		if len(fullstr) < 0 {
-			continue
-		}
+	group := quarklog.NewLogGroup(*dir, *base)
+
+	if *follow {
+		runFollow(group, f, *output)
+		return
+	}
+
+	r, err := group.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	byTask := make(map[string]quarklog.Entry)
+	byVCPU := make(map[string]quarklog.Entry)
 
-		str := fullstr[0:]
-
-		substr := "";
-		if strings.HasPrefix(str, "[ERROR] [") {
-			substr = strings.TrimPrefix(str, "[ERROR] [")
-		} else if strings.HasPrefix(str, "[INFO] [") {
-			substr = strings.TrimPrefix(str, "[INFO] [")
-		} else if strings.HasPrefix(str, "[Print] [") {
-			substr = strings.TrimPrefix(str, "[Print] [")
-		} else if strings.HasPrefix(str, "[DEBUG] [") {
-			substr = strings.TrimPrefix(str, "[DEBUG] [")
-		} else {
+	sc := quarklog.NewScanner(r)
+	for sc.Scan() {
+		e := sc.Entry()
+		if !f.match(e) {
 			continue
 		}
+		byTask[e.TaskID] = e
+		byVCPU[e.VCPU] = e
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	w, err := newWriter(os.Stdout, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	writeSortedByLineNo(w, byTask)
+
+	if *output == "text" {
+		fmt.Println("vpus is:")
+	}
+	writeSortedByLineNo(w, byVCPU)
+}
+
+func runFollow(group *quarklog.LogGroup, f *filter, output string) {
+	w, err := newWriter(os.Stdout, output)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		//start := strings.Index(substr, "[")
-		first := strings.Index(substr, "]")
-		left := strings.Index(substr, "/")
-		right := strings.Index(substr, "|")
-		// fmt.Printf("substr: %v, len is %v \n", substr, len(substr));
-		if len(substr) <= 12 || first==-1 || left == -1 || right == -1 || left > right {
-			continue;
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+
+	err = group.Follow(stop, func(e quarklog.Entry) {
+		if !f.match(e) {
+			return
 		}
+		w.write(e)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeSortedByLineNo writes the entries of m to w in ascending LineNo
+// order.
+//
+// This collects m's values with a manual loop rather than maps.Values
+// (stdlib maps.Values/slices.Collect need Go 1.23+) because go.mod pins
+// go 1.21 to match this repo's build toolchain; bumping the directive
+// would make the module unbuildable here.
+func writeSortedByLineNo(w entryWriter, m map[string]quarklog.Entry) {
+	entries := make([]quarklog.Entry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b quarklog.Entry) int { return a.LineNo - b.LineNo })
+	for _, e := range entries {
+		w.write(e)
+	}
+}
+
+// filter decides whether an Entry should be included in the CLI's
+// output, based on the --level/--since/--until/--vcpu/--task/--grep
+// flags.
+type filter struct {
+	levels map[string]bool
+	since  time.Time
+	until  time.Time
+	vcpu   string
+	task   string
+	grep   *regexp.Regexp
+}
+
+func newFilter(level, since, until, vcpu, task, grep string) (*filter, error) {
+	// TaskID is parsed without its leading "/" (see quarklog.Parse's
+	// vcpu/task split on "/"), but --task is commonly given with one
+	// (e.g. --task=/foo/bar), so strip it to match.
+	task = strings.TrimPrefix(task, "/")
 
-		idstr := substr[left:right];
-		if strings.Index(idstr, "/") == -1 {
-			continue;
+	f := &filter{vcpu: vcpu, task: task}
+
+	if level != "" {
+		f.levels = make(map[string]bool)
+		for _, l := range strings.Split(level, ",") {
+			f.levels[strings.TrimSpace(l)] = true
 		}
-		//fmt.Printf("idstr is %v\n", idstr);
+	}
 
-		m[idstr]= line {
-			num: num,
-			str: str,
+	if since != "" {
+		t, err := parseTimeArg(since)
+		if err != nil {
+			return nil, fmt.Errorf("--since: %w", err)
+		}
+		f.since = t
+	}
+	if until != "" {
+		t, err := parseTimeArg(until)
+		if err != nil {
+			return nil, fmt.Errorf("--until: %w", err)
 		}
+		f.until = t
+	}
 
-		cpuStr := substr[0:left];
-		vcpus[cpuStr]= line {
-			num: num,
-			str: str,
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, fmt.Errorf("--grep: %w", err)
 		}
+		f.grep = re
+	}
+
+	return f, nil
+}
+
+// parseTimeArg parses a --since/--until value as either a duration
+// relative to now (e.g. "10m" meaning 10 minutes ago) or an absolute
+// timestamp in quarklog's own layout or RFC 3339.
+func parseTimeArg(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(quarklog.TimestampLayout, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("not a duration or timestamp: %q", s)
+}
+
+func (f *filter) match(e quarklog.Entry) bool {
+	if f.levels != nil && !f.levels[e.Level] {
+		return false
+	}
+	if !f.since.IsZero() && e.Timestamp.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && e.Timestamp.After(f.until) {
+		return false
+	}
+	if f.vcpu != "" && e.VCPU != f.vcpu {
+		return false
 	}
+	if f.task != "" && e.TaskID != f.task {
+		return false
+	}
+	if f.grep != nil && !f.grep.MatchString(e.Message) {
+		return false
+	}
+	return true
+}
 
-	primes := []line{}
-	for _, line := range m {
-		primes = append(primes, line)
+// entryWriter renders Entries to an output stream in one of the CLI's
+// supported formats.
+type entryWriter interface {
+	write(e quarklog.Entry)
+}
+
+func newWriter(w io.Writer, format string) (entryWriter, error) {
+	switch format {
+	case "text":
+		return &textWriter{w: w}, nil
+	case "json":
+		return &jsonWriter{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		return newCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown --output format %q (want text, json, or csv)", format)
 	}
+}
+
+type textWriter struct{ w io.Writer }
+
+func (t *textWriter) write(e quarklog.Entry) { fmt.Fprintln(t.w, e.Raw) }
+
+type jsonWriter struct{ enc *json.Encoder }
+
+func (j *jsonWriter) write(e quarklog.Entry) { j.enc.Encode(e) }
 
-	sort.Sort(Lines(primes))
-	for _, str := range primes {
-		fmt.Println(str)
+type csvWriter struct {
+	w          *csv.Writer
+	wroteTitle bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) write(e quarklog.Entry) {
+	if !c.wroteTitle {
+		c.w.Write([]string{"lineNo", "level", "timestamp", "vcpu", "taskId", "category", "message"})
+		c.wroteTitle = true
 	}
+	c.w.Write([]string{
+		strconv.Itoa(e.LineNo),
+		e.Level,
+		e.Timestamp.Format(quarklog.TimestampLayout),
+		e.VCPU,
+		e.TaskID,
+		e.Category,
+		e.Message,
+	})
+	c.w.Flush()
+}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+// runTimeline implements the `quarklog timeline` subcommand: it
+// reconstructs per-vCPU and per-task scheduling history from a log
+// group and renders it as either an ASCII swimlane or a Chrome trace.
+func runTimeline(args []string) error {
+	fs := flag.NewFlagSet("quarklog timeline", flag.ExitOnError)
+	dir := fs.String("dir", "/var/log/quark", "directory containing the quark log and its rotated siblings")
+	base := fs.String("base", "quark.log", "base name of the log file within --dir")
+	format := fs.String("format", "swimlane", "output format: swimlane or json (Chrome trace)")
+	fs.Parse(args)
+
+	r, err := quarklog.NewLogGroup(*dir, *base).Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var entries []quarklog.Entry
+	sc := quarklog.NewScanner(r)
+	for sc.Scan() {
+		entries = append(entries, sc.Entry())
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	t := timeline.Build(entries)
+
+	switch *format {
+	case "swimlane":
+		t.WriteSwimlane(os.Stdout)
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(t.Trace())
+	default:
+		return fmt.Errorf("unknown --format %q (want swimlane or json)", *format)
+	}
+	return nil
+}
+
+// runSearch implements the `quarklog search` subcommand: it builds a
+// keyword/full-text index over a log group (or loads a previously
+// persisted one) and answers a --query keyword lookup or a --regex
+// full-text search against it.
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("quarklog search", flag.ExitOnError)
+	dir := fs.String("dir", "/var/log/quark", "directory containing the quark log and its rotated siblings")
+	base := fs.String("base", "quark.log", "base name of the log file within --dir")
+	query := fs.String("query", "", "look up an exact keyword: a vCPU id, task id, category, or message token")
+	grep := fs.String("regex", "", "full-text search the message bodies with this regexp")
+	indexFile := fs.String("index-file", "", "load a previously persisted index from this path instead of rebuilding it")
+	saveIndex := fs.String("save-index", "", "persist the built index to this path for reuse by a later --index-file run")
+	fs.Parse(args)
+
+	if *query == "" && *grep == "" {
+		return fmt.Errorf("quarklog search: one of --query or --regex is required")
+	}
+
+	ix, err := loadOrBuildIndex(*dir, *base, *indexFile)
+	if err != nil {
+		return err
 	}
 
-	lines := []line{}
-	for _, line := range vcpus {
-		lines = append(lines, line)
+	if *saveIndex != "" {
+		f, err := os.Create(*saveIndex)
+		if err != nil {
+			return fmt.Errorf("quarklog: creating %s: %w", *saveIndex, err)
+		}
+		defer f.Close()
+		if err := ix.Persist(f); err != nil {
+			return fmt.Errorf("quarklog: persisting index to %s: %w", *saveIndex, err)
+		}
 	}
-	sort.Sort(Lines(lines))
 
+	var hits []index.Hit
+	if *query != "" {
+		hits = ix.Lookup(*query)
+	} else {
+		re, err := regexp.Compile(*grep)
+		if err != nil {
+			return fmt.Errorf("--regex: %w", err)
+		}
+		hits = ix.Search(re)
+	}
+
+	printHits(os.Stdout, ix, hits)
+	return nil
+}
 
-	fmt.Println("vpus is:");
-	for _, line := range lines {
-		fmt.Println(line)
+// loadOrBuildIndex loads a persisted index from indexFile if given, or
+// otherwise builds one from scratch over the log group at dir/base.
+func loadOrBuildIndex(dir, base, indexFile string) (*index.Index, error) {
+	if indexFile != "" {
+		f, err := os.Open(indexFile)
+		if err != nil {
+			return nil, fmt.Errorf("quarklog: opening %s: %w", indexFile, err)
+		}
+		defer f.Close()
+		return index.Load(f)
+	}
+
+	group := quarklog.NewLogGroup(dir, base)
+	paths, err := group.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	b := index.NewBuilder()
+	for fileID, path := range paths {
+		r, err := quarklog.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sc := quarklog.NewScanner(r)
+		for sc.Scan() {
+			b.Add(fileID, sc.Entry())
+		}
+		err = sc.Err()
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish(), nil
+}
+
+// printHits renders Hits as one line per matching spot: the keyword,
+// the task/vCPU it occurred under, and the stored snippet text. Hits
+// from Index.Search carry no task or vCPU, so those fields print empty
+// for --regex results.
+func printHits(w io.Writer, ix *index.Index, hits []index.Hit) {
+	for _, hit := range hits {
+		for _, task := range hit.Runs {
+			for _, run := range task.Runs {
+				for _, spot := range run.Spots {
+					fmt.Fprintf(w, "%s\ttask=%s\tvcpu=%s\t%s\n", hit.Word, task.TaskID, run.VCPU, ix.Snippets[spot.SnippetID])
+				}
+			}
+		}
 	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junli1026/Quark/pkg/quarklog"
+)
+
+func mustEntry(t *testing.T, raw string) quarklog.Entry {
+	t.Helper()
+	e, err := quarklog.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", raw, err)
+	}
+	return e
+}
+
+func TestFilterMatch(t *testing.T) {
+	errEntry := mustEntry(t, "[ERROR] [2026-07-26 17:16:20.123456] [cpu3/foo/bar|EPTviolation] page fault at 0x1000")
+	infoEntry := mustEntry(t, "[INFO] [2026-07-26 17:16:30.000000] [cpu1/baz/qux|Sched] task scheduled")
+
+	cases := []struct {
+		name                                  string
+		level, since, until, vcpu, task, grep string
+		want                                  []bool // matches errEntry, infoEntry
+	}{
+		{"no filters", "", "", "", "", "", "", []bool{true, true}},
+		{"level", "ERROR", "", "", "", "", "", []bool{true, false}},
+		{"vcpu", "", "", "", "cpu1", "", "", []bool{false, true}},
+		{"task", "", "", "", "", "baz/qux", "", []bool{false, true}},
+		{"task with leading slash", "", "", "", "", "/baz/qux", "", []bool{false, true}},
+		{"grep", "", "", "", "", "", "scheduled", []bool{false, true}},
+		{"since excludes earlier entry", "", "2026-07-26T17:16:25Z", "", "", "", "", []bool{false, true}},
+		{"until excludes later entry", "", "", "2026-07-26T17:16:25Z", "", "", "", []bool{true, false}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := newFilter(c.level, c.since, c.until, c.vcpu, c.task, c.grep)
+			if err != nil {
+				t.Fatalf("newFilter error: %v", err)
+			}
+			if got := f.match(errEntry); got != c.want[0] {
+				t.Errorf("match(errEntry) = %v, want %v", got, c.want[0])
+			}
+			if got := f.match(infoEntry); got != c.want[1] {
+				t.Errorf("match(infoEntry) = %v, want %v", got, c.want[1])
+			}
+		})
+	}
+}
+
+func TestParseTimeArgDuration(t *testing.T) {
+	before := time.Now().Add(-10 * time.Minute)
+	got, err := parseTimeArg("10m")
+	if err != nil {
+		t.Fatalf("parseTimeArg(10m) error: %v", err)
+	}
+	after := time.Now().Add(-10 * time.Minute)
+	if got.Before(before.Add(-time.Second)) || got.After(after.Add(time.Second)) {
+		t.Fatalf("parseTimeArg(10m) = %v, want close to 10 minutes ago", got)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	e := mustEntry(t, "[ERROR] [2026-07-26 17:16:20.123456] [cpu3/foo/bar|EPTviolation] page fault at 0x1000")
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := newWriter(&buf, "text")
+		if err != nil {
+			t.Fatalf("newWriter(text) error: %v", err)
+		}
+		w.write(e)
+		if got := buf.String(); got != e.Raw+"\n" {
+			t.Fatalf("text output = %q, want %q", got, e.Raw+"\n")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := newWriter(&buf, "json")
+		if err != nil {
+			t.Fatalf("newWriter(json) error: %v", err)
+		}
+		w.write(e)
+		if !strings.Contains(buf.String(), `"vcpu":"cpu3"`) {
+			t.Fatalf("json output = %q, want it to contain the vcpu field", buf.String())
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := newWriter(&buf, "csv")
+		if err != nil {
+			t.Fatalf("newWriter(csv) error: %v", err)
+		}
+		w.write(e)
+		lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("csv output has %d lines, want a header + 1 row: %q", len(lines), buf.String())
+		}
+		if !strings.HasPrefix(lines[0], "lineNo,level,timestamp,vcpu,taskId,category,message") {
+			t.Fatalf("csv header = %q, want the Entry field names", lines[0])
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := newWriter(&bytes.Buffer{}, "xml"); err == nil {
+			t.Fatal("newWriter(xml) succeeded, want an error for an unsupported format")
+		}
+	})
+}
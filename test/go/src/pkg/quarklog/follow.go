@@ -0,0 +1,151 @@
+package quarklog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// errFollowStopped is returned internally by reopen's retry loop when
+// stop closes while it's waiting for the rotated-in file to appear.
+var errFollowStopped = errors.New("quarklog: follow stopped")
+
+// Follow tails the group's live file, calling fn with each new Entry
+// as it is appended. It detects truncation (the file shrinks, as
+// happens with copytruncate rotation) and rename/remove (as happens
+// when the live file is renamed aside and a fresh one created in its
+// place), reopening the live path from the start whenever either
+// occurs. Follow blocks until stop is closed or it hits an
+// unrecoverable error.
+func (g *LogGroup) Follow(stop <-chan struct{}, fn func(Entry)) error {
+	path := filepath.Join(g.Dir, g.Base)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("quarklog: creating watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(g.Dir); err != nil {
+		return fmt.Errorf("quarklog: watching %s: %w", g.Dir, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("quarklog: opening %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("quarklog: seeking %s: %w", path, err)
+	}
+
+	r := bufio.NewReader(f)
+	lineNo := 0
+
+	drain := func() error {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				if err == io.EOF && line != "" {
+					// A partial line was written so far; rewind to
+					// it and wait for the rest to arrive.
+					if _, serr := f.Seek(-int64(len(line)), io.SeekCurrent); serr != nil {
+						return serr
+					}
+					r = bufio.NewReader(f)
+				}
+				return nil
+			}
+			lineNo++
+			e, perr := Parse(strings.TrimRight(line, "\n"))
+			if perr != nil {
+				continue
+			}
+			e.LineNo = lineNo
+			fn(e)
+		}
+	}
+
+	// reopen re-opens path after it's been renamed away or removed.
+	// Typical non-copytruncate rotation (rename the live file aside,
+	// then create a fresh one in its place) has a window where the
+	// rename event fires before the replacement exists, so this waits
+	// for it with a backoff rather than failing outright.
+	reopen := func() error {
+		f.Close()
+		backoff := 10 * time.Millisecond
+		for {
+			newF, err := os.Open(path)
+			if err == nil {
+				f = newF
+				r = bufio.NewReader(f)
+				lineNo = 0
+				return nil
+			}
+			if !os.IsNotExist(err) {
+				return fmt.Errorf("quarklog: reopening %s: %w", path, err)
+			}
+			select {
+			case <-stop:
+				return errFollowStopped
+			case <-time.After(backoff):
+			}
+			if backoff < 500*time.Millisecond {
+				backoff *= 2
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Name != path {
+				continue
+			}
+			switch {
+			case event.Op&(fsnotify.Rename|fsnotify.Remove) != 0:
+				if err := reopen(); err != nil {
+					if err == errFollowStopped {
+						return nil
+					}
+					return err
+				}
+				if err := drain(); err != nil {
+					return err
+				}
+			case event.Op&fsnotify.Write != 0:
+				if fi, err := f.Stat(); err == nil {
+					if pos, _ := f.Seek(0, io.SeekCurrent); fi.Size() < pos {
+						if _, err := f.Seek(0, io.SeekStart); err != nil {
+							return err
+						}
+						r = bufio.NewReader(f)
+						lineNo = 0
+					}
+				}
+				if err := drain(); err != nil {
+					return err
+				}
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("quarklog: watcher error: %w", err)
+		}
+	}
+}
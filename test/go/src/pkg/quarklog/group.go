@@ -0,0 +1,153 @@
+package quarklog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LogGroup discovers the rotated log files produced for a single base
+// name (e.g. "quark.log", "quark.log.1", "quark.log.2.gz", ...) within
+// a directory, modeled on the common rotating-file-group pattern: one
+// live file plus a numbered series of older, optionally gzipped,
+// siblings.
+type LogGroup struct {
+	Dir  string
+	Base string
+}
+
+// NewLogGroup returns a LogGroup for the rotated files of base within
+// dir.
+func NewLogGroup(dir, base string) *LogGroup {
+	return &LogGroup{Dir: dir, Base: base}
+}
+
+// rotatedFile is a single file discovered in the group, with the
+// rotation index parsed out of its name (0 for the live file).
+type rotatedFile struct {
+	path  string
+	index int
+}
+
+var rotationSuffix = regexp.MustCompile(`^\.(\d+)(\.gz)?$`)
+
+// Files returns the group's files in chronological order: oldest
+// rotated file first, live file last.
+func (g *LogGroup) Files() ([]string, error) {
+	entries, err := os.ReadDir(g.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("quarklog: reading log dir %s: %w", g.Dir, err)
+	}
+
+	var files []rotatedFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), g.Base) {
+			continue
+		}
+		name := entry.Name()
+		if name == g.Base {
+			files = append(files, rotatedFile{path: filepath.Join(g.Dir, name), index: 0})
+			continue
+		}
+		m := rotationSuffix.FindStringSubmatch(strings.TrimPrefix(name, g.Base))
+		if m == nil {
+			continue
+		}
+		idx, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		files = append(files, rotatedFile{path: filepath.Join(g.Dir, name), index: idx})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		// Larger rotation indexes are older; the live file (index 0)
+		// is newest and always sorts last.
+		if files[i].index == 0 {
+			return false
+		}
+		if files[j].index == 0 {
+			return true
+		}
+		return files[i].index > files[j].index
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// groupReader concatenates a LogGroup's files and closes all of them
+// together.
+type groupReader struct {
+	r       io.Reader
+	closers []io.Closer
+}
+
+func (g *groupReader) Read(p []byte) (int, error) { return g.r.Read(p) }
+
+func (g *groupReader) Close() error {
+	var err error
+	for i := len(g.closers) - 1; i >= 0; i-- {
+		if cerr := g.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// OpenFile opens a single file from the group, transparently
+// gunzipping it if its name ends in ".gz". Closing the result closes
+// the underlying file.
+func OpenFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("quarklog: opening %s: %w", path, err)
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("quarklog: gunzipping %s: %w", path, err)
+	}
+	return &groupReader{r: gz, closers: []io.Closer{gz, f}}, nil
+}
+
+// Open returns a single ReadCloser over all of the group's files, in
+// chronological order, transparently gunzipping ".gz" members. Pass
+// the result to NewScanner to stream Entries across the whole
+// retention window. Closing it closes every underlying file.
+func (g *LogGroup) Open() (io.ReadCloser, error) {
+	paths, err := g.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var readers []io.Reader
+	var closers []io.Closer
+	for _, path := range paths {
+		rc, err := OpenFile(path)
+		if err != nil {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i].Close()
+			}
+			return nil, err
+		}
+		closers = append(closers, rc)
+		readers = append(readers, rc)
+	}
+
+	return &groupReader{r: io.MultiReader(readers...), closers: closers}, nil
+}
@@ -0,0 +1,83 @@
+package quarklog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func writeGzipFile(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatalf("gzipping %s: %v", path, err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("closing gzip writer for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestLogGroupFilesChronologicalOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "quark.log"), "live\n")
+	writeFile(t, filepath.Join(dir, "quark.log.1"), "newest-rotated\n")
+	writeGzipFile(t, filepath.Join(dir, "quark.log.2.gz"), "oldest-rotated\n")
+	writeFile(t, filepath.Join(dir, "unrelated.log"), "ignored\n")
+
+	g := NewLogGroup(dir, "quark.log")
+	files, err := g.Files()
+	if err != nil {
+		t.Fatalf("Files() error: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "quark.log.2.gz"),
+		filepath.Join(dir, "quark.log.1"),
+		filepath.Join(dir, "quark.log"),
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Files() = %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("Files()[%d] = %q, want %q (full: %v)", i, files[i], want[i], files)
+		}
+	}
+}
+
+func TestLogGroupOpenConcatenatesAndGunzips(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "quark.log"), "line-live\n")
+	writeGzipFile(t, filepath.Join(dir, "quark.log.1.gz"), "line-rotated\n")
+
+	g := NewLogGroup(dir, "quark.log")
+	r, err := g.Open()
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading combined stream: %v", err)
+	}
+
+	want := "line-rotated\nline-live\n"
+	if string(got) != want {
+		t.Fatalf("combined stream = %q, want %q", got, want)
+	}
+}
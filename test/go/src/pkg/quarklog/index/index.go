@@ -0,0 +1,253 @@
+// Package index builds searchable indexes over a stream of parsed
+// quarklog Entries, in the spirit of godoc's identifier/full-text
+// index: a keyword index that groups hits hierarchically, and a
+// full-text suffix array over the message bodies.
+package index
+
+import (
+	"encoding/gob"
+	"index/suffixarray"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/junli1026/Quark/pkg/quarklog"
+)
+
+// SpotInfo locates a single occurrence of a keyword: which file it came
+// from, which line within that file, and which snippet (the formatted
+// line text) describes it.
+type SpotInfo struct {
+	FileID    int
+	LineNo    int
+	SnippetID int
+}
+
+// VCPURun groups the SpotInfos for a keyword that occurred while a
+// given vCPU was running the enclosing TaskRun's task.
+type VCPURun struct {
+	VCPU  string
+	Spots []SpotInfo
+}
+
+// TaskRun groups the VCPURuns for a keyword that occurred under a given
+// task id.
+type TaskRun struct {
+	TaskID string
+	Runs   []VCPURun
+}
+
+// Hit is a single keyword's grouped occurrences, as returned by Lookup
+// and Search.
+type Hit struct {
+	Word string
+	Runs []TaskRun
+}
+
+// rawSpot is a single, unreduced occurrence of a keyword, recorded
+// during Builder.Add.
+type rawSpot struct {
+	task string
+	vcpu string
+	spot SpotInfo
+}
+
+// textOffset maps a byte offset in the concatenated message text back
+// to the (file, line) it came from.
+type textOffset struct {
+	Start  int
+	FileID int
+	LineNo int
+}
+
+// Index is a built, queryable index over a set of Entries. Build one
+// with Builder, or restore one with Load.
+type Index struct {
+	Words    map[string][]TaskRun
+	Snippets []string
+
+	text    []byte
+	offsets []textOffset
+	sa      *suffixarray.Index
+}
+
+// Builder accumulates Entries and reduces them into an Index on
+// Finish, mirroring godoc's two-pass indexer: collect raw spots per
+// word, then reduce them hierarchically.
+type Builder struct {
+	raw      map[string][]rawSpot
+	snippets []string
+	text     strings.Builder
+	offsets  []textOffset
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{raw: make(map[string][]rawSpot)}
+}
+
+// Add ingests a parsed Entry from the file identified by fileID,
+// recording it under the vCPU id, task id, category, and message
+// tokens it contains, and appending its message to the full-text
+// corpus.
+func (b *Builder) Add(fileID int, e quarklog.Entry) {
+	snippetID := len(b.snippets)
+	b.snippets = append(b.snippets, e.Raw)
+
+	spot := SpotInfo{FileID: fileID, LineNo: e.LineNo, SnippetID: snippetID}
+	for _, word := range keywords(e) {
+		b.raw[word] = append(b.raw[word], rawSpot{task: e.TaskID, vcpu: e.VCPU, spot: spot})
+	}
+
+	b.offsets = append(b.offsets, textOffset{Start: b.text.Len(), FileID: fileID, LineNo: e.LineNo})
+	b.text.WriteString(e.Message)
+	b.text.WriteByte('\n')
+}
+
+// keywords extracts the identifier/keyword tokens worth indexing from
+// an Entry: its vCPU id, task id, category, and the space-separated
+// tokens of its message (a cheap stand-in for function/syscall names).
+func keywords(e quarklog.Entry) []string {
+	words := make([]string, 0, 4)
+	if e.VCPU != "" {
+		words = append(words, e.VCPU)
+	}
+	if e.TaskID != "" {
+		words = append(words, e.TaskID)
+	}
+	if e.Category != "" {
+		words = append(words, e.Category)
+	}
+	words = append(words, strings.Fields(e.Message)...)
+	return words
+}
+
+// Finish reduces the accumulated spots into an Index. Spots are
+// reduced the same way godoc reduces spots to FileRuns to PakRuns:
+// here, Spots -> VCPURuns -> TaskRuns, so a Lookup returns hits
+// grouped by task id and, within each task, by the vCPUs it touched.
+func (b *Builder) Finish() *Index {
+	words := make(map[string][]TaskRun, len(b.raw))
+	for word, spots := range b.raw {
+		words[word] = reduce(spots)
+	}
+
+	text := []byte(b.text.String())
+	return &Index{
+		Words:    words,
+		Snippets: b.snippets,
+		text:     text,
+		offsets:  b.offsets,
+		sa:       suffixarray.New(text),
+	}
+}
+
+// reduce groups a word's raw spots by task, then by vCPU within each
+// task, sorting at every level so Lookup results are stable.
+func reduce(spots []rawSpot) []TaskRun {
+	byTask := make(map[string]map[string][]SpotInfo)
+	for _, s := range spots {
+		byVCPU, ok := byTask[s.task]
+		if !ok {
+			byVCPU = make(map[string][]SpotInfo)
+			byTask[s.task] = byVCPU
+		}
+		byVCPU[s.vcpu] = append(byVCPU[s.vcpu], s.spot)
+	}
+
+	runs := make([]TaskRun, 0, len(byTask))
+	for task, byVCPU := range byTask {
+		vcpuRuns := make([]VCPURun, 0, len(byVCPU))
+		for vcpu, spots := range byVCPU {
+			sort.Slice(spots, func(i, j int) bool { return spots[i].LineNo < spots[j].LineNo })
+			vcpuRuns = append(vcpuRuns, VCPURun{VCPU: vcpu, Spots: spots})
+		}
+		sort.Slice(vcpuRuns, func(i, j int) bool { return vcpuRuns[i].VCPU < vcpuRuns[j].VCPU })
+		runs = append(runs, TaskRun{TaskID: task, Runs: vcpuRuns})
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].TaskID < runs[j].TaskID })
+	return runs
+}
+
+// Lookup returns the grouped hit for an exact keyword (a vCPU id, task
+// id, category, or message token), or nil if the keyword was never
+// indexed.
+func (ix *Index) Lookup(query string) []Hit {
+	runs, ok := ix.Words[query]
+	if !ok {
+		return nil
+	}
+	return []Hit{{Word: query, Runs: runs}}
+}
+
+// Search runs re over the concatenated message bodies via the
+// full-text suffix array, mapping each match back to the (file, line)
+// it came from.
+func (ix *Index) Search(re *regexp.Regexp) []Hit {
+	matches := ix.sa.FindAllIndex(re, -1)
+	if matches == nil {
+		return nil
+	}
+	hits := make([]Hit, 0, len(matches))
+	for _, m := range matches {
+		off, snippetID := ix.offsetFor(m[0])
+		hits = append(hits, Hit{
+			Word: string(ix.text[m[0]:m[1]]),
+			Runs: []TaskRun{{
+				Runs: []VCPURun{{
+					Spots: []SpotInfo{{FileID: off.FileID, LineNo: off.LineNo, SnippetID: snippetID}},
+				}},
+			}},
+		})
+	}
+	return hits
+}
+
+// offsetFor returns the textOffset covering byte position pos, along
+// with its index, which doubles as the SnippetID: offsets and
+// Snippets are parallel slices built in lockstep by Builder.Add.
+func (ix *Index) offsetFor(pos int) (textOffset, int) {
+	i := sort.Search(len(ix.offsets), func(i int) bool { return ix.offsets[i].Start > pos }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return ix.offsets[i], i
+}
+
+// persisted is the on-disk gob encoding of an Index; the suffix array
+// itself is rebuilt on Load rather than serialized.
+type persisted struct {
+	Words    map[string][]TaskRun
+	Snippets []string
+	Text     []byte
+	Offsets  []textOffset
+}
+
+// Persist gob-encodes the Index to w so a later run can Load and query
+// it without re-scanning the log files. A loaded Index cannot be
+// extended: there is no way to add further Entries to it, so indexing
+// newly rotated files means building a new Index over all of them.
+func (ix *Index) Persist(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(persisted{
+		Words:    ix.Words,
+		Snippets: ix.Snippets,
+		Text:     ix.text,
+		Offsets:  ix.offsets,
+	})
+}
+
+// Load restores an Index previously written by Persist.
+func Load(r io.Reader) (*Index, error) {
+	var p persisted
+	if err := gob.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	return &Index{
+		Words:    p.Words,
+		Snippets: p.Snippets,
+		text:     p.Text,
+		offsets:  p.Offsets,
+		sa:       suffixarray.New(p.Text),
+	}, nil
+}
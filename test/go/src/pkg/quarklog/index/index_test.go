@@ -0,0 +1,82 @@
+package index
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/junli1026/Quark/pkg/quarklog"
+)
+
+func buildTestIndex(t *testing.T) *Index {
+	t.Helper()
+	b := NewBuilder()
+	b.Add(0, quarklog.Entry{LineNo: 1, VCPU: "cpu3", TaskID: "foo/bar", Category: "EPTviolation", Message: "page fault at 0x1000", Raw: "line1"})
+	b.Add(0, quarklog.Entry{LineNo: 2, VCPU: "cpu1", TaskID: "foo/bar", Category: "Sched", Message: "task scheduled on cpu1", Raw: "line2"})
+	b.Add(1, quarklog.Entry{LineNo: 1, VCPU: "cpu3", TaskID: "baz/qux", Category: "Sched", Message: "task scheduled on cpu3", Raw: "line3"})
+	return b.Finish()
+}
+
+func TestLookupGroupsByTaskThenVCPU(t *testing.T) {
+	ix := buildTestIndex(t)
+
+	hits := ix.Lookup("foo/bar")
+	if len(hits) != 1 {
+		t.Fatalf("Lookup(foo/bar) returned %d hits, want 1", len(hits))
+	}
+	runs := hits[0].Runs
+	if len(runs) != 1 || runs[0].TaskID != "foo/bar" {
+		t.Fatalf("Lookup(foo/bar) runs = %+v, want a single TaskRun for foo/bar", runs)
+	}
+	if len(runs[0].Runs) != 2 {
+		t.Fatalf("foo/bar ran on %d vCPUs, want 2 (cpu1 and cpu3): %+v", len(runs[0].Runs), runs[0].Runs)
+	}
+	// VCPURuns are sorted by vCPU id.
+	if runs[0].Runs[0].VCPU != "cpu1" || runs[0].Runs[1].VCPU != "cpu3" {
+		t.Fatalf("VCPURuns = %+v, want [cpu1, cpu3] in order", runs[0].Runs)
+	}
+}
+
+func TestLookupUnknownKeyword(t *testing.T) {
+	ix := buildTestIndex(t)
+	if hits := ix.Lookup("no-such-keyword"); hits != nil {
+		t.Fatalf("Lookup(no-such-keyword) = %+v, want nil", hits)
+	}
+}
+
+func TestSearchPopulatesSnippetID(t *testing.T) {
+	ix := buildTestIndex(t)
+
+	hits := ix.Search(regexp.MustCompile("scheduled"))
+	if len(hits) != 2 {
+		t.Fatalf("Search(scheduled) returned %d hits, want 2", len(hits))
+	}
+	for _, hit := range hits {
+		spot := hit.Runs[0].Runs[0].Spots[0]
+		got := ix.Snippets[spot.SnippetID]
+		if got != "line2" && got != "line3" {
+			t.Fatalf("Search hit SnippetID %d resolved to %q, want the snippet for the matched line (line2 or line3)", spot.SnippetID, got)
+		}
+	}
+}
+
+func TestPersistLoadRoundTrip(t *testing.T) {
+	ix := buildTestIndex(t)
+
+	var buf bytes.Buffer
+	if err := ix.Persist(&buf); err != nil {
+		t.Fatalf("Persist() error: %v", err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if len(loaded.Lookup("foo/bar")) != len(ix.Lookup("foo/bar")) {
+		t.Fatalf("loaded index disagrees with original on Lookup(foo/bar)")
+	}
+	if len(loaded.Search(regexp.MustCompile("scheduled"))) != 2 {
+		t.Fatalf("loaded index's Search(scheduled) did not return 2 hits")
+	}
+}
@@ -0,0 +1,123 @@
+// Package quarklog parses Quark hypervisor log lines into structured
+// Entry values and provides a streaming Scanner over a reader of such
+// lines.
+//
+// A line has the form:
+//
+//	[LEVEL] [TIMESTAMP] [VCPU/TaskID|Category] Message
+//
+// e.g.
+//
+//	[ERROR] [2026-07-26 17:16:20.123456] [cpu3/foo/bar|EPTviolation] page fault at 0x1000
+package quarklog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TimestampLayout is the format Quark uses for the bracketed timestamp
+// in each log line, suitable for time.Parse and time.Format alike.
+const TimestampLayout = "2006-01-02 15:04:05.000000"
+
+// Entry is a single parsed log line.
+type Entry struct {
+	LineNo    int       `json:"lineNo"`
+	Level     string    `json:"level"`
+	Timestamp time.Time `json:"timestamp"`
+	VCPU      string    `json:"vcpu"`
+	TaskID    string    `json:"taskId"`
+	Category  string    `json:"category"`
+	Message   string    `json:"message"`
+	Raw       string    `json:"raw"`
+}
+
+// Parse parses a single Quark log line into an Entry. LineNo is left
+// zero; callers that know the line's position (e.g. Scanner) fill it
+// in.
+func Parse(line string) (Entry, error) {
+	head, rest, ok := strings.Cut(line, "] [")
+	if !ok {
+		return Entry{}, fmt.Errorf("quarklog: missing level bracket: %q", line)
+	}
+	level := strings.TrimPrefix(head, "[")
+
+	tsStr, rest, ok := strings.Cut(rest, "] [")
+	if !ok {
+		return Entry{}, fmt.Errorf("quarklog: missing timestamp bracket: %q", line)
+	}
+	ts, err := time.Parse(TimestampLayout, tsStr)
+	if err != nil {
+		return Entry{}, fmt.Errorf("quarklog: bad timestamp %q: %w", tsStr, err)
+	}
+
+	idPart, message, ok := strings.Cut(rest, "] ")
+	if !ok {
+		return Entry{}, fmt.Errorf("quarklog: missing identifier bracket: %q", line)
+	}
+
+	vcpu, idRest, ok := strings.Cut(idPart, "/")
+	if !ok {
+		return Entry{}, fmt.Errorf("quarklog: missing vcpu/task separator: %q", line)
+	}
+	taskID, category, ok := strings.Cut(idRest, "|")
+	if !ok {
+		return Entry{}, fmt.Errorf("quarklog: missing task/category separator: %q", line)
+	}
+
+	return Entry{
+		Level:     level,
+		Timestamp: ts,
+		VCPU:      vcpu,
+		TaskID:    taskID,
+		Category:  category,
+		Message:   message,
+		Raw:       line,
+	}, nil
+}
+
+// Scanner reads Quark log lines from an underlying io.Reader and parses
+// them into Entries, in the style of bufio.Scanner. Lines that fail to
+// parse are skipped.
+type Scanner struct {
+	sc     *bufio.Scanner
+	lineNo int
+	cur    Entry
+	err    error
+}
+
+// NewScanner returns a Scanner that reads and parses lines from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{sc: bufio.NewScanner(r)}
+}
+
+// Scan advances the Scanner to the next parseable Entry, returning
+// false when no more entries are available. Call Entry to retrieve the
+// result and Err to check for a read error.
+func (s *Scanner) Scan() bool {
+	for s.sc.Scan() {
+		s.lineNo++
+		e, err := Parse(s.sc.Text())
+		if err != nil {
+			continue
+		}
+		e.LineNo = s.lineNo
+		s.cur = e
+		return true
+	}
+	s.err = s.sc.Err()
+	return false
+}
+
+// Entry returns the most recent Entry produced by Scan.
+func (s *Scanner) Entry() Entry {
+	return s.cur
+}
+
+// Err returns the first non-EOF error encountered while reading.
+func (s *Scanner) Err() error {
+	return s.err
+}
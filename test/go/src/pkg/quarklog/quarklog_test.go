@@ -0,0 +1,75 @@
+package quarklog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	const line = "[ERROR] [2026-07-26 17:16:20.123456] [cpu3/foo/bar|EPTviolation] page fault at 0x1000"
+
+	e, err := Parse(line)
+	if err != nil {
+		t.Fatalf("Parse(%q) returned error: %v", line, err)
+	}
+
+	want := Entry{
+		Level:     "ERROR",
+		Timestamp: time.Date(2026, 7, 26, 17, 16, 20, 123456000, time.UTC),
+		VCPU:      "cpu3",
+		TaskID:    "foo/bar",
+		Category:  "EPTviolation",
+		Message:   "page fault at 0x1000",
+		Raw:       line,
+	}
+	if e.Level != want.Level || !e.Timestamp.Equal(want.Timestamp) || e.VCPU != want.VCPU ||
+		e.TaskID != want.TaskID || e.Category != want.Category || e.Message != want.Message || e.Raw != want.Raw {
+		t.Fatalf("Parse(%q) = %+v, want %+v", line, e, want)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+	}{
+		{"no level bracket", "plain log line with no brackets at all"},
+		{"missing timestamp bracket", "[ERROR] cpu3/foo/bar|EPTviolation] page fault"},
+		{"bad timestamp", "[ERROR] [not-a-time] [cpu3/foo/bar|EPTviolation] page fault"},
+		{"missing identifier bracket", "[ERROR] [2026-07-26 17:16:20.123456] cpu3/foo/bar|EPTviolation page fault"},
+		{"missing vcpu separator", "[ERROR] [2026-07-26 17:16:20.123456] [cpu3foobar|EPTviolation] page fault"},
+		{"missing task separator", "[ERROR] [2026-07-26 17:16:20.123456] [cpu3/foobarEPTviolation] page fault"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Parse(c.line); err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error", c.line)
+			}
+		})
+	}
+}
+
+func TestScannerSkipsMalformedLines(t *testing.T) {
+	const input = `[ERROR] [2026-07-26 17:16:20.123456] [cpu3/foo/bar|EPTviolation] page fault at 0x1000
+this line is not a quark log line and should be skipped
+[INFO] [2026-07-26 17:16:21.000000] [cpu3/foo/bar|Sched] task scheduled
+`
+	sc := NewScanner(strings.NewReader(input))
+
+	var got []Entry
+	for sc.Scan() {
+		got = append(got, sc.Entry())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("Scanner.Err() = %v, want nil", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(got), got)
+	}
+	if got[0].LineNo != 1 || got[1].LineNo != 3 {
+		t.Fatalf("got LineNos %d, %d, want 1, 3 (the malformed line 2 should be skipped but still counted)", got[0].LineNo, got[1].LineNo)
+	}
+}
@@ -0,0 +1,139 @@
+// Package timeline reconstructs vCPU scheduling history from a stream
+// of quarklog Entries. Where a simple "last line per key" dedup loses
+// ordering, a Timeline keeps every task-scheduling transition, so it
+// can answer both "what ran on this vCPU, in order?" and "which vCPUs
+// did this task run on, in order?".
+package timeline
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/junli1026/Quark/pkg/quarklog"
+)
+
+// Span is one uninterrupted stretch of time during which a single task
+// id was running on a single vCPU.
+type Span struct {
+	VCPU      string
+	TaskID    string
+	Start     time.Time
+	End       time.Time
+	StartLine int
+	EndLine   int
+}
+
+// Timeline groups a set of Spans both by the vCPU that ran them and by
+// the task id that was running.
+type Timeline struct {
+	ByVCPU map[string][]Span
+	ByTask map[string][]Span
+}
+
+// Build reconstructs a Timeline from entries. entries need not be
+// pre-sorted; Build sorts a copy by LineNo before scanning for
+// transitions.
+func Build(entries []quarklog.Entry) *Timeline {
+	sorted := append([]quarklog.Entry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LineNo < sorted[j].LineNo })
+
+	open := make(map[string]*Span) // vcpu -> currently open span
+	var spans []Span
+
+	for _, e := range sorted {
+		cur, ok := open[e.VCPU]
+		switch {
+		case !ok:
+			open[e.VCPU] = &Span{VCPU: e.VCPU, TaskID: e.TaskID, Start: e.Timestamp, End: e.Timestamp, StartLine: e.LineNo, EndLine: e.LineNo}
+		case cur.TaskID == e.TaskID:
+			cur.End = e.Timestamp
+			cur.EndLine = e.LineNo
+		default:
+			spans = append(spans, *cur)
+			open[e.VCPU] = &Span{VCPU: e.VCPU, TaskID: e.TaskID, Start: e.Timestamp, End: e.Timestamp, StartLine: e.LineNo, EndLine: e.LineNo}
+		}
+	}
+	for _, cur := range open {
+		spans = append(spans, *cur)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].StartLine < spans[j].StartLine })
+
+	t := &Timeline{ByVCPU: make(map[string][]Span), ByTask: make(map[string][]Span)}
+	for _, s := range spans {
+		t.ByVCPU[s.VCPU] = append(t.ByVCPU[s.VCPU], s)
+		t.ByTask[s.TaskID] = append(t.ByTask[s.TaskID], s)
+	}
+	return t
+}
+
+// WriteSwimlane renders an ASCII swimlane, one line per vCPU, showing
+// the task ids it ran in order.
+func (t *Timeline) WriteSwimlane(w io.Writer) {
+	vcpus := make([]string, 0, len(t.ByVCPU))
+	for vcpu := range t.ByVCPU {
+		vcpus = append(vcpus, vcpu)
+	}
+	sort.Strings(vcpus)
+
+	for _, vcpu := range vcpus {
+		fmt.Fprintf(w, "%s:", vcpu)
+		for _, s := range t.ByVCPU[vcpu] {
+			fmt.Fprintf(w, " [%s %s-%s]", s.TaskID, s.Start.Format("15:04:05.000000"), s.End.Format("15:04:05.000000"))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// TraceEvent is a single Chrome Tracing Format event, as consumed by
+// chrome://tracing and most flamegraph viewers.
+type TraceEvent struct {
+	Name string            `json:"name"`
+	Ph   string            `json:"ph"`
+	Ts   float64           `json:"ts"`
+	Dur  float64           `json:"dur,omitempty"`
+	Pid  int               `json:"pid"`
+	Tid  int               `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// Trace is a Chrome Tracing Format document: a flat list of
+// traceEvents.
+type Trace struct {
+	TraceEvents []TraceEvent `json:"traceEvents"`
+}
+
+// Trace renders the Timeline as a Chrome trace: one thread per vCPU,
+// one complete ("X") event per Span, plus thread_name metadata events
+// so viewers label lanes with the vCPU id instead of a bare index.
+func (t *Timeline) Trace() Trace {
+	vcpus := make([]string, 0, len(t.ByVCPU))
+	for vcpu := range t.ByVCPU {
+		vcpus = append(vcpus, vcpu)
+	}
+	sort.Strings(vcpus)
+
+	var tr Trace
+	for tid, vcpu := range vcpus {
+		tr.TraceEvents = append(tr.TraceEvents, TraceEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			Pid:  0,
+			Tid:  tid,
+			Args: map[string]string{"name": vcpu},
+		})
+		for _, s := range t.ByVCPU[vcpu] {
+			tr.TraceEvents = append(tr.TraceEvents, TraceEvent{
+				Name: s.TaskID,
+				Ph:   "X",
+				Ts:   float64(s.Start.UnixNano()) / 1e3,
+				Dur:  float64(s.End.Sub(s.Start).Nanoseconds()) / 1e3,
+				Pid:  0,
+				Tid:  tid,
+			})
+		}
+	}
+	return tr
+}
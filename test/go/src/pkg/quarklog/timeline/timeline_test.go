@@ -0,0 +1,71 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/junli1026/Quark/pkg/quarklog"
+)
+
+func ts(sec int) time.Time {
+	return time.Date(2026, 7, 26, 17, 16, sec, 0, time.UTC)
+}
+
+func TestBuildSplitsSpansOnTaskTransition(t *testing.T) {
+	entries := []quarklog.Entry{
+		{LineNo: 1, VCPU: "cpu3", TaskID: "foo/bar", Timestamp: ts(0)},
+		{LineNo: 2, VCPU: "cpu3", TaskID: "foo/bar", Timestamp: ts(1)},
+		{LineNo: 3, VCPU: "cpu3", TaskID: "baz/qux", Timestamp: ts(2)},
+		{LineNo: 4, VCPU: "cpu1", TaskID: "baz/qux", Timestamp: ts(3)},
+	}
+
+	tl := Build(entries)
+
+	cpu3Spans := tl.ByVCPU["cpu3"]
+	if len(cpu3Spans) != 2 {
+		t.Fatalf("cpu3 has %d spans, want 2 (one per distinct task run): %+v", len(cpu3Spans), cpu3Spans)
+	}
+	if cpu3Spans[0].TaskID != "foo/bar" || cpu3Spans[0].StartLine != 1 || cpu3Spans[0].EndLine != 2 {
+		t.Fatalf("cpu3's first span = %+v, want foo/bar spanning lines 1-2", cpu3Spans[0])
+	}
+	if cpu3Spans[1].TaskID != "baz/qux" || cpu3Spans[1].StartLine != 3 || cpu3Spans[1].EndLine != 3 {
+		t.Fatalf("cpu3's second span = %+v, want baz/qux spanning line 3", cpu3Spans[1])
+	}
+
+	bazQuxSpans := tl.ByTask["baz/qux"]
+	if len(bazQuxSpans) != 2 {
+		t.Fatalf("baz/qux ran in %d spans, want 2 (cpu3 then cpu1): %+v", len(bazQuxSpans), bazQuxSpans)
+	}
+	if bazQuxSpans[0].VCPU != "cpu3" || bazQuxSpans[1].VCPU != "cpu1" {
+		t.Fatalf("baz/qux's vCPU order = %+v, want [cpu3, cpu1]", bazQuxSpans)
+	}
+}
+
+func TestTraceEmitsOneCompleteEventPerSpan(t *testing.T) {
+	entries := []quarklog.Entry{
+		{LineNo: 1, VCPU: "cpu3", TaskID: "foo/bar", Timestamp: ts(0)},
+		{LineNo: 2, VCPU: "cpu3", TaskID: "foo/bar", Timestamp: ts(1)},
+	}
+	tl := Build(entries)
+
+	trace := tl.Trace()
+
+	var complete, meta int
+	for _, ev := range trace.TraceEvents {
+		switch ev.Ph {
+		case "X":
+			complete++
+			if ev.Name != "foo/bar" {
+				t.Errorf("complete event name = %q, want foo/bar", ev.Name)
+			}
+		case "M":
+			meta++
+		}
+	}
+	if complete != 1 {
+		t.Fatalf("got %d complete events, want 1 (one span)", complete)
+	}
+	if meta != 1 {
+		t.Fatalf("got %d thread_name metadata events, want 1 (one vCPU)", meta)
+	}
+}